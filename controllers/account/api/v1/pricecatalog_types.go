@@ -0,0 +1,85 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PriceCatalogProperty is the CR-editable mirror of resources.PropertyType.
+// It is kept as its own type (rather than embedding resources.PropertyType
+// directly) so the CRD schema doesn't leak the runtime-only Unit field.
+type PriceCatalogProperty struct {
+	Name  string `json:"name"`
+	Alias string `json:"alias,omitempty"`
+	// Enum is the storage enum this property is keyed by in Monitor/Metering
+	// documents; see resources.PropertyType.Enum.
+	Enum uint8 `json:"enum"`
+	// PriceType is "AVG" or "SUM"; defaults to "AVG" when empty.
+	PriceType string `json:"priceType,omitempty"`
+	// UnitPrice is ignored when EncryptUnitPrice is set.
+	UnitPrice        int64  `json:"unitPrice,omitempty"`
+	EncryptUnitPrice string `json:"encryptUnitPrice,omitempty"`
+	UnitString       string `json:"unit"`
+	UnitPeriod       string `json:"unitPeriod,omitempty"`
+}
+
+// PriceCatalogSpec defines the desired property catalog.
+type PriceCatalogSpec struct {
+	// Properties replaces the entire active property catalog when this CR
+	// is reconciled. PriceCatalogReconciler does not choose between several
+	// PriceCatalogs: the one reconciled last is the one in effect.
+	Properties []PriceCatalogProperty `json:"properties"`
+}
+
+// PriceCatalogStatus surfaces the generation that is currently in effect so
+// operators can confirm a price change has rolled out.
+type PriceCatalogStatus struct {
+	// ObservedGeneration is the .metadata.generation last reconciled.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// ActiveGeneration is the .metadata.generation currently serving
+	// billing/metering, i.e. the last one that reconciled without error.
+	ActiveGeneration int64       `json:"activeGeneration,omitempty"`
+	LastAppliedTime  metav1.Time `json:"lastAppliedTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+
+// PriceCatalog makes the billing property catalog (previously only the
+// "prices" MongoDB collection, loaded once at controller startup) a
+// reconciled, auditable Kubernetes object. When present it is authoritative
+// over MongoDB; see resources.SetActivePropertyTypeLS.
+type PriceCatalog struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PriceCatalogSpec   `json:"spec,omitempty"`
+	Status PriceCatalogStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// PriceCatalogList contains a list of PriceCatalog.
+type PriceCatalogList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PriceCatalog `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PriceCatalog{}, &PriceCatalogList{})
+}
@@ -0,0 +1,102 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/labring/sealos/controllers/pkg/resources"
+	"github.com/labring/sealos/pkg/utils/logger"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var billingChainTamperedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "sealos_billing_chain_tampered_total",
+	Help: "Number of owner billing chains found with a broken hash link by the periodic audit.",
+}, []string{"owner"})
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(billingChainTamperedTotal)
+}
+
+// BillingChainAuditor periodically walks every owner's billing chain with
+// resources.VerifyBillingChain and flags the first divergence it finds, so
+// DB-level tampering shows up as a metric and an error log instead of silently
+// surviving until a tenant disputes an invoice.
+type BillingChainAuditor struct {
+	MongoClient *mongo.Client
+	Interval    time.Duration
+}
+
+// Run audits on Interval until ctx is cancelled.
+func (a *BillingChainAuditor) Run(ctx context.Context) error {
+	if a.Interval == 0 {
+		a.Interval = 24 * time.Hour
+	}
+	ticker := time.NewTicker(a.Interval)
+	defer ticker.Stop()
+	for {
+		if err := a.auditOnce(ctx); err != nil {
+			logger.Warn("billing chain audit: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (a *BillingChainAuditor) auditOnce(ctx context.Context) error {
+	owners, err := a.distinctOwners(ctx)
+	if err != nil {
+		return fmt.Errorf("list billing owners: %v", err)
+	}
+	for _, owner := range owners {
+		divergence, err := resources.VerifyBillingChain(ctx, a.MongoClient, owner)
+		if err != nil {
+			logger.Warn("verify billing chain for %s: %v", owner, err)
+			continue
+		}
+		if divergence == nil {
+			continue
+		}
+		billingChainTamperedTotal.WithLabelValues(owner).Inc()
+		logger.Error("billing chain tampered: owner=%s order=%s expected=%s found=%s",
+			owner, divergence.OrderID, divergence.Expected, divergence.Found)
+	}
+	return nil
+}
+
+func (a *BillingChainAuditor) distinctOwners(ctx context.Context) ([]string, error) {
+	collection := a.MongoClient.Database(resources.SealosResourcesDBName).Collection(resources.SealosBillingCollectionName)
+	raw, err := collection.Distinct(ctx, "owner", map[string]interface{}{})
+	if err != nil {
+		return nil, err
+	}
+	owners := make([]string, 0, len(raw))
+	for _, v := range raw {
+		if s, ok := v.(string); ok && s != "" {
+			owners = append(owners, s)
+		}
+	}
+	return owners, nil
+}
@@ -0,0 +1,174 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	accountv1 "github.com/labring/sealos/controllers/account/api/v1"
+	"github.com/labring/sealos/controllers/pkg/crypto"
+	"github.com/labring/sealos/controllers/pkg/resources"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// PriceCatalogReconciler watches the cluster-scoped PriceCatalog singleton
+// and rebuilds resources.DefaultPropertyTypeLS whenever it changes, so a
+// price update takes effect without a restart for every reader that goes
+// through resources.ActivePropertyTypeLS. A *resources.PropertyTypeLS taken
+// before the swap keeps the old catalog.
+type PriceCatalogReconciler struct {
+	client.Client
+	Recorder    record.EventRecorder
+	MongoClient *mongo.Client
+}
+
+// +kubebuilder:rbac:groups=account.sealos.io,resources=pricecatalogs,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=account.sealos.io,resources=pricecatalogs/status,verbs=get;update;patch
+
+func (r *PriceCatalogReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	catalog := &accountv1.PriceCatalog{}
+	if err := r.Get(ctx, req.NamespacedName, catalog); err != nil {
+		if errors.IsNotFound(err) {
+			// No CR (yet): fall back to whatever MongoDB has, same as before
+			// PriceCatalog existed.
+			return ctrl.Result{}, r.bootstrapFromMongo(ctx)
+		}
+		return ctrl.Result{}, fmt.Errorf("get price catalog: %v", err)
+	}
+
+	propertyTypes := toPropertyTypes(catalog)
+	if len(propertyTypes) == 0 {
+		return ctrl.Result{}, fmt.Errorf("price catalog %s has no properties", catalog.Name)
+	}
+
+	// Decrypt per entry: NewPropertyTypeLS would replace the whole catalog
+	// with the defaults as soon as one entry has no EncryptUnitPrice.
+	for i := range propertyTypes {
+		if propertyTypes[i].EncryptUnitPrice == "" {
+			continue
+		}
+		price, err := crypto.DecryptInt64(propertyTypes[i].EncryptUnitPrice)
+		if err != nil {
+			return ctrl.Result{}, fmt.Errorf("decrypt %s unit price: %v", propertyTypes[i].Name, err)
+		}
+		propertyTypes[i].UnitPrice = price
+	}
+
+	previous := resources.ActivePropertyTypeLS()
+	resources.SetActivePropertyTypeLS(resources.NewPropertyTypeLSFromPlainPrices(propertyTypes))
+	r.recordPriceChanges(catalog, previous, propertyTypes)
+
+	catalog.Status.ObservedGeneration = catalog.Generation
+	catalog.Status.ActiveGeneration = catalog.Generation
+	if err := r.Status().Update(ctx, catalog); err != nil {
+		return ctrl.Result{}, fmt.Errorf("update price catalog status: %v", err)
+	}
+
+	logger.Info("reconciled price catalog", "generation", catalog.Generation, "properties", len(propertyTypes))
+	return ctrl.Result{}, nil
+}
+
+// toPropertyTypes converts a PriceCatalog's CR-side spec into the resources
+// package's runtime representation. This lives here, not on PriceCatalog
+// itself, so that controllers/account/api/v1 doesn't have to import
+// controllers/pkg/resources - resources already imports accountv1 (for
+// Billing.Type), and that import must stay one-directional.
+func toPropertyTypes(catalog *accountv1.PriceCatalog) []resources.PropertyType {
+	out := make([]resources.PropertyType, 0, len(catalog.Spec.Properties))
+	for _, prop := range catalog.Spec.Properties {
+		out = append(out, resources.PropertyType{
+			Name:             prop.Name,
+			Alias:            prop.Alias,
+			Enum:             prop.Enum,
+			PriceType:        prop.PriceType,
+			UnitPrice:        prop.UnitPrice,
+			EncryptUnitPrice: prop.EncryptUnitPrice,
+			UnitString:       prop.UnitString,
+			UnitPeriod:       prop.UnitPeriod,
+		})
+	}
+	return out
+}
+
+// bootstrapFromMongo overlays unit prices from the legacy "prices"
+// collection onto the existing catalog when no PriceCatalog CR has been
+// created yet. A Price document only carries a property name and price, not
+// the Enum/UnitString/PriceType a property needs to bill correctly, so it
+// cannot describe a catalog on its own: building property types straight
+// from Price (as this once did) defaulted every entry to Enum 0, collapsing
+// the whole catalog onto enum 0's EnumMap slot. Instead, only the UnitPrice
+// of properties the current catalog already knows about is updated; anyone
+// else is left untouched and the default catalog keeps shape.
+func (r *PriceCatalogReconciler) bootstrapFromMongo(ctx context.Context) error {
+	if r.MongoClient == nil {
+		return nil
+	}
+	prices, err := resources.GetPrices(r.MongoClient)
+	if err != nil {
+		return fmt.Errorf("bootstrap price catalog from mongo: %v", err)
+	}
+	if len(prices) == 0 {
+		return nil
+	}
+	current := resources.ActivePropertyTypeLS()
+	if current == nil {
+		return nil
+	}
+	propertyTypes := make([]resources.PropertyType, len(current.Types))
+	copy(propertyTypes, current.Types)
+	for _, p := range prices {
+		for i := range propertyTypes {
+			if propertyTypes[i].Name == p.Property {
+				propertyTypes[i].UnitPrice = p.Price
+				break
+			}
+		}
+	}
+	resources.SetActivePropertyTypeLS(resources.NewPropertyTypeLSFromPlainPrices(propertyTypes))
+	return nil
+}
+
+// recordPriceChanges emits a Kubernetes event for every property whose
+// UnitPrice changed, so a price update is auditable from `kubectl describe`
+// rather than only visible in a diff of the CR spec.
+func (r *PriceCatalogReconciler) recordPriceChanges(catalog *accountv1.PriceCatalog, previous *resources.PropertyTypeLS, next []resources.PropertyType) {
+	if r.Recorder == nil || previous == nil {
+		return
+	}
+	for _, n := range next {
+		old, ok := previous.StringMap[n.Name]
+		if ok && old.UnitPrice == n.UnitPrice {
+			continue
+		}
+		r.Recorder.Eventf(catalog, "Normal", "PriceChanged",
+			"property %q unit price changed from %d to %d", n.Name, old.UnitPrice, n.UnitPrice)
+	}
+}
+
+func (r *PriceCatalogReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&accountv1.PriceCatalog{}).
+		Complete(r)
+}
@@ -0,0 +1,161 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// BillingChainHMACKeyEnv names the environment variable holding the
+// deployment-specific key used to authenticate billing chain hashes. It has
+// no built-in default: this is a server-side tamper-evidence check, not a
+// tenant-verifiable signature - anyone who knows the key can forge a valid
+// Hash/PrevHash, so a key shipped in source (or a hardcoded fallback) would
+// let anyone with Mongo write access recompute it after editing Amount, and
+// the chain would detect nothing.
+const BillingChainHMACKeyEnv = "BILLING_CHAIN_HMAC_KEY"
+
+// billingChainHMACKey resolves the signing key from the environment on
+// every call, failing closed when it is unset rather than falling back to a
+// known constant. Callers (AppendBillingReceipt, VerifyBillingChain) must
+// surface this error rather than skip signing/verification.
+func billingChainHMACKey() ([]byte, error) {
+	key := os.Getenv(BillingChainHMACKeyEnv)
+	if key == "" {
+		return nil, fmt.Errorf("%s is not set: refusing to sign or verify the billing chain with no key", BillingChainHMACKeyEnv)
+	}
+	return []byte(key), nil
+}
+
+// ComputeBillingHash returns the chained-hash receipt for a Billing
+// document: HMAC-SHA256(canonical_json(doc without Hash) || PrevHash),
+// keyed by BillingChainHMACKeyEnv. An HMAC - not a randomized signature
+// scheme like RSA-PSS/ECDSA - is required here: VerifyBillingChain
+// recomputes this hash from the stored doc and compares it byte-for-byte,
+// so the same input must always produce the same output. This makes the
+// chain detect server-side tampering by anyone without the key; it is not a
+// signature tenants can verify independently, since there is no per-tenant
+// or asymmetric keypair anywhere in this controller to check it against.
+// b.PrevHash must already be set to the previous billing doc's Hash for
+// this Owner (or empty, for the first entry in the chain).
+func ComputeBillingHash(b Billing) (string, error) {
+	key, err := billingChainHMACKey()
+	if err != nil {
+		return "", err
+	}
+	b.Hash = ""
+	// Mongo stores Time at millisecond precision; truncate it before
+	// marshaling so a hash computed from an in-memory Billing (sub-second
+	// precision) matches the hash recomputed from the same doc after a
+	// BSON round-trip, instead of every doc looking tampered.
+	// The driver also decodes Time in UTC, so normalize the location too.
+	b.Time = b.Time.UTC().Truncate(time.Millisecond)
+	payload, err := json.Marshal(b)
+	if err != nil {
+		return "", fmt.Errorf("marshal billing doc for hashing: %v", err)
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	mac.Write([]byte(b.PrevHash))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// AppendBillingReceipt looks up the last billing doc for b.Owner, assigns
+// the next Seq in its chain, chains b onto it via PrevHash, stamps b.Hash,
+// and returns the ready-to-insert doc. Callers should insert the returned
+// doc rather than b directly.
+func AppendBillingReceipt(ctx context.Context, mongoClient *mongo.Client, b Billing) (Billing, error) {
+	collection := mongoClient.Database(SealosResourcesDBName).Collection(SealosBillingCollectionName)
+	// Chain position is ordered by Seq, not Time: two receipts for the same
+	// owner can share a Time (same billing bucket), which would leave their
+	// PrevHash linkage undefined and race under concurrent inserts.
+	opts := options.FindOne().SetSort(bson.M{"seq": -1})
+	var last Billing
+	err := collection.FindOne(ctx, bson.M{"owner": b.Owner}, opts).Decode(&last)
+	switch {
+	case err == mongo.ErrNoDocuments:
+		b.Seq = 0
+		b.PrevHash = ""
+	case err != nil:
+		return Billing{}, fmt.Errorf("find last billing doc for %s: %v", b.Owner, err)
+	default:
+		b.Seq = last.Seq + 1
+		b.PrevHash = last.Hash
+	}
+	hash, err := ComputeBillingHash(b)
+	if err != nil {
+		return Billing{}, err
+	}
+	b.Hash = hash
+	return b, nil
+}
+
+// ChainDivergence describes the first billing document in an owner's chain
+// whose PrevHash does not link to the previous document's Hash, or whose
+// stored Hash no longer matches its recomputed hash, i.e. the first sign of
+// tampering. Expected/Found hold the PrevHash values in the first case and
+// the Hash values in the second.
+type ChainDivergence struct {
+	OrderID  string
+	Seq      int64
+	Time     time.Time
+	Expected string
+	Found    string
+}
+
+// VerifyBillingChain walks owner's billing history in Seq order and reports
+// the first entry whose PrevHash doesn't match the previous entry's Hash or
+// whose Hash doesn't match ComputeBillingHash, or nil if the whole chain
+// verifies.
+func VerifyBillingChain(ctx context.Context, mongoClient *mongo.Client, owner string) (*ChainDivergence, error) {
+	collection := mongoClient.Database(SealosResourcesDBName).Collection(SealosBillingCollectionName)
+	cursor, err := collection.Find(ctx, bson.M{"owner": owner}, options.Find().SetSort(bson.M{"seq": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("find billing chain for %s: %v", owner, err)
+	}
+	defer cursor.Close(ctx)
+
+	var docs []Billing
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("decode billing chain for %s: %v", owner, err)
+	}
+
+	prevHash := ""
+	for _, doc := range docs {
+		if doc.PrevHash != prevHash {
+			return &ChainDivergence{OrderID: doc.OrderID, Seq: doc.Seq, Time: doc.Time, Expected: prevHash, Found: doc.PrevHash}, nil
+		}
+		want, err := ComputeBillingHash(doc)
+		if err != nil {
+			return nil, err
+		}
+		if want != doc.Hash {
+			return &ChainDivergence{OrderID: doc.OrderID, Seq: doc.Seq, Time: doc.Time, Expected: want, Found: doc.Hash}, nil
+		}
+		prevHash = doc.Hash
+	}
+	return nil, nil
+}
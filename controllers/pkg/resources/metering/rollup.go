@@ -0,0 +1,184 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metering rolls the raw per-window Monitor samples written by the
+// cpu/memory/storage/network collectors up into Metering documents, the
+// input BillingHandler bills from.
+package metering
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/labring/sealos/controllers/pkg/resources"
+	"github.com/labring/sealos/pkg/utils/logger"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Collector periodically folds the Monitor documents written in the last
+// UnitPeriod into one Metering document per (category, property), using
+// resources.RollupValue so each property is folded per its own PriceType:
+// AVG properties (cpu/memory/storage occupancy) average their samples, SUM
+// properties (network bandwidth deltas) add them up instead of averaging
+// them away. A property with Tiers configured (tiered storage pricing) is
+// billed with resources.TieredStorageAmount against its StorageAge samples
+// instead, when any are on hand for the window.
+type Collector struct {
+	MongoClient *mongo.Client
+	// UnitPeriod is both the rollup window and how often it runs; it
+	// defaults to 1 hour, matching PropertyType.UnitPeriod for the existing
+	// cpu/memory/storage properties.
+	UnitPeriod time.Duration
+}
+
+// Run rolls up on UnitPeriod until ctx is cancelled.
+func (c *Collector) Run(ctx context.Context) error {
+	if c.UnitPeriod == 0 {
+		c.UnitPeriod = time.Hour
+	}
+	ticker := time.NewTicker(c.UnitPeriod)
+	defer ticker.Stop()
+	for {
+		if err := c.rollupOnce(ctx); err != nil {
+			logger.Warn("metering rollup: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// sampleKey groups Monitor.Used values by the namespace and property enum
+// they were reported for, so each group rolls up into its own Metering doc.
+type sampleKey struct {
+	category string
+	enum     uint8
+}
+
+func (c *Collector) rollupOnce(ctx context.Context) error {
+	since := time.Now().Add(-c.UnitPeriod)
+	monitorCollection := c.MongoClient.Database(resources.SealosResourcesDBName).Collection(resources.SealosMonitorCollectionName)
+	cursor, err := monitorCollection.Find(ctx, bson.M{resources.TimeField: bson.M{"$gte": since}})
+	if err != nil {
+		return fmt.Errorf("find monitor samples: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var docs []resources.Monitor
+	if err := cursor.All(ctx, &docs); err != nil {
+		return fmt.Errorf("decode monitor samples: %v", err)
+	}
+
+	samples := make(map[sampleKey][]int64)
+	for _, doc := range docs {
+		for enum, value := range doc.Used {
+			key := sampleKey{category: doc.Category, enum: enum}
+			samples[key] = append(samples[key], value)
+		}
+	}
+
+	storageAges, err := c.latestStorageAges(ctx, since)
+	if err != nil {
+		return err
+	}
+
+	ls := resources.ActivePropertyTypeLS()
+	meteringCollection := c.MongoClient.Database(resources.SealosResourcesDBName).Collection(resources.SealosMeteringCollectionName)
+	now := time.Now().UTC()
+	for key, values := range samples {
+		pt, ok := ls.EnumMap[key.enum]
+		if !ok {
+			logger.Warn("metering rollup: no property for enum %d in category %s, dropping %d samples", key.enum, key.category, len(values))
+			continue
+		}
+		value := resources.RollupValue(pt, values)
+		amount := value * pt.UnitPrice
+		if len(pt.Tiers) > 0 {
+			if ages := storageAges[key.category]; len(ages) > 0 {
+				// Amount and Value both come from the same inventory
+				// snapshot here, not from the (now irrelevant) averaged
+				// Monitor occupancy samples, so the two stay consistent
+				// for a tiered property.
+				amount = resources.TieredStorageAmount(pt, ages)
+				value = sumSizeMiB(ages)
+			}
+		}
+		doc := resources.Metering{
+			Category: key.category,
+			Property: pt.Name,
+			Value:    value,
+			Amount:   amount,
+			Time:     now,
+		}
+		if _, err := meteringCollection.InsertOne(ctx, doc); err != nil {
+			return fmt.Errorf("insert metering doc for %s/%s: %v", key.category, pt.Name, err)
+		}
+	}
+	return nil
+}
+
+// storageAgeKey identifies one scanned object across repeated inventory
+// scans, so latestStorageAges can keep only its newest scan.
+type storageAgeKey struct {
+	category string
+	name     string
+}
+
+// latestStorageAges fetches every StorageAge document in the rollup window
+// in a single query and keeps only the newest scan per (category, Name).
+// storage.Collector's scan interval is independent of this rollup's
+// UnitPeriod, so more than one scan of the same object can land inside one
+// window; summing all of them would bill that object once per scan instead
+// of once per window.
+func (c *Collector) latestStorageAges(ctx context.Context, since time.Time) (map[string][]resources.StorageAge, error) {
+	collection := c.MongoClient.Database(resources.SealosResourcesDBName).Collection(resources.SealosStorageAgeCollectionName)
+	cursor, err := collection.Find(ctx, bson.M{resources.TimeField: bson.M{"$gte": since}})
+	if err != nil {
+		return nil, fmt.Errorf("find storage ages: %v", err)
+	}
+	defer cursor.Close(ctx)
+	var docs []resources.StorageAge
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("decode storage ages: %v", err)
+	}
+
+	latest := make(map[storageAgeKey]resources.StorageAge, len(docs))
+	for _, a := range docs {
+		key := storageAgeKey{category: a.Category, name: a.Name}
+		if existing, ok := latest[key]; !ok || a.Time.After(existing.Time) {
+			latest[key] = a
+		}
+	}
+	byCategory := make(map[string][]resources.StorageAge, len(latest))
+	for key, a := range latest {
+		byCategory[key.category] = append(byCategory[key.category], a)
+	}
+	return byCategory, nil
+}
+
+// sumSizeMiB totals SizeMiB across a namespace's deduplicated StorageAge
+// samples, so Metering.Value reflects the same inventory snapshot
+// Metering.Amount was billed from.
+func sumSizeMiB(ages []resources.StorageAge) int64 {
+	var total int64
+	for _, a := range ages {
+		total += a.SizeMiB
+	}
+	return total
+}
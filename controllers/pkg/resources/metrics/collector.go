@@ -0,0 +1,163 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metrics turns the monitor/metering/billing MongoDB collections
+// into Prometheus time series, the same way kube-state-metrics turns
+// cluster objects into time series.
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/labring/sealos/controllers/pkg/resources"
+	"github.com/labring/sealos/pkg/utils/logger"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	meteringValue = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "sealos_metering_value",
+		Help: "Latest raw metered value (average or cumulative, depending on PriceType) per category/property.",
+	}, []string{"category", "property"})
+
+	meteringAmount = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "sealos_metering_amount",
+		Help: "Latest billed amount derived from the metering value per category/property.",
+	}, []string{"category", "property"})
+
+	billingAmountTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "sealos_billing_amount_total",
+		Help: "Cumulative billed amount observed in the billing collection, by billing type and settlement status.",
+	}, []string{"type", "status"})
+
+	unitPrice = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "sealos_property_unit_price",
+		Help: "Configured unit price for a property, so operators can alert on pricing drift.",
+	}, []string{"property"})
+)
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(meteringValue, meteringAmount, billingAmountTotal, unitPrice)
+}
+
+// Collector polls the monitor/metering/billing collections on an interval
+// and republishes their contents as Prometheus metrics. It is meant to run
+// alongside the controllers that already use BillingHandler/PropertyTypeLS.
+type Collector struct {
+	MongoClient *mongo.Client
+	PropertyLS  *resources.PropertyTypeLS
+	// PollInterval is how often recent documents are sampled; it defaults
+	// to 1 minute when zero.
+	PollInterval time.Duration
+
+	// lastBillingTime is the Time of the newest billing doc already folded
+	// into billingAmountTotal; only docs strictly newer than it are counted
+	// on the next poll, so billingAmountTotal never double-counts without
+	// having to remember every order ID ever seen.
+	lastBillingTime time.Time
+}
+
+// Start runs the collector loop until ctx is cancelled.
+func (c *Collector) Start(ctx context.Context) error {
+	if c.PollInterval == 0 {
+		c.PollInterval = time.Minute
+	}
+	ticker := time.NewTicker(c.PollInterval)
+	defer ticker.Stop()
+	for {
+		if err := c.collectOnce(ctx); err != nil {
+			logger.Warn("metrics collector: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Collector) collectOnce(ctx context.Context) error {
+	since := time.Now().Add(-2 * c.PollInterval)
+	if err := c.collectMetering(ctx, since); err != nil {
+		return err
+	}
+	if err := c.collectBilling(ctx); err != nil {
+		return err
+	}
+	for _, pt := range c.PropertyLS.Types {
+		unitPrice.WithLabelValues(pt.Name).Set(float64(pt.UnitPrice))
+	}
+	return nil
+}
+
+func (c *Collector) collectMetering(ctx context.Context, since time.Time) error {
+	collection := c.MongoClient.Database(resources.SealosResourcesDBName).Collection(resources.SealosMeteringCollectionName)
+	cursor, err := collection.Find(ctx, bson.M{resources.TimeField: bson.M{"$gte": since}},
+		options.Find().SetSort(bson.M{resources.TimeField: -1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+	var docs []resources.Metering
+	if err := cursor.All(ctx, &docs); err != nil {
+		return err
+	}
+	// docs are sorted newest-first; only the first doc seen for a given
+	// (category, property) is its latest value, so later, older docs for
+	// the same pair must not overwrite it.
+	seen := make(map[[2]string]struct{}, len(docs))
+	for _, m := range docs {
+		key := [2]string{m.Category, m.Property}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		meteringValue.WithLabelValues(m.Category, m.Property).Set(float64(m.Value))
+		meteringAmount.WithLabelValues(m.Category, m.Property).Set(float64(m.Amount))
+	}
+	return nil
+}
+
+func (c *Collector) collectBilling(ctx context.Context) error {
+	collection := c.MongoClient.Database(resources.SealosResourcesDBName).Collection(resources.SealosBillingCollectionName)
+	cursor, err := collection.Find(ctx, bson.M{resources.TimeField: bson.M{"$gt": c.lastBillingTime}},
+		options.Find().SetSort(bson.M{resources.TimeField: 1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+	var docs []resources.Billing
+	if err := cursor.All(ctx, &docs); err != nil {
+		return err
+	}
+	for _, b := range docs {
+		status := "unsettled"
+		if b.Status == resources.Settled {
+			status = "settled"
+		}
+		billingAmountTotal.WithLabelValues(fmt.Sprintf("%v", b.Type), status).Add(float64(b.Amount))
+		if b.Time.After(c.lastBillingTime) {
+			c.lastBillingTime = b.Time
+		}
+	}
+	return nil
+}
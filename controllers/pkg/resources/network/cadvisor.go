@@ -0,0 +1,109 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package network
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	metricReceiveBytes  = "container_network_receive_bytes_total"
+	metricTransmitBytes = "container_network_transmit_bytes_total"
+)
+
+// CadvisorSampler implements Sampler by scraping a node's kubelet
+// /metrics/cadvisor endpoint through the API server proxy and summing the
+// per-container network counters by namespace.
+type CadvisorSampler struct {
+	Client kubernetes.Interface
+}
+
+func (s *CadvisorSampler) Sample(ctx context.Context, node string) (map[string]NamespaceCounters, error) {
+	raw, err := s.Client.CoreV1().RESTClient().Get().
+		Resource("nodes").
+		Name(node).
+		SubResource("proxy").
+		Suffix("metrics/cadvisor").
+		DoRaw(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("proxy metrics/cadvisor on node %s: %v", node, err)
+	}
+	return parseCadvisorNetworkMetrics(raw), nil
+}
+
+// parseCadvisorNetworkMetrics reads the Prometheus text-exposition format
+// emitted by cAdvisor and sums the named network counters per pod
+// namespace label. Unrelated metric families are skipped.
+func parseCadvisorNetworkMetrics(raw []byte) map[string]NamespaceCounters {
+	totals := make(map[string]NamespaceCounters)
+	scanner := bufio.NewScanner(strings.NewReader(string(raw)))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var metric string
+		switch {
+		case strings.HasPrefix(line, metricReceiveBytes):
+			metric = metricReceiveBytes
+		case strings.HasPrefix(line, metricTransmitBytes):
+			metric = metricTransmitBytes
+		default:
+			continue
+		}
+		ns, value, ok := parseSample(line)
+		if !ok || ns == "" {
+			continue
+		}
+		agg := totals[ns]
+		if metric == metricReceiveBytes {
+			agg.IngressBytes += value
+		} else {
+			agg.EgressBytes += value
+		}
+		totals[ns] = agg
+	}
+	return totals
+}
+
+// parseSample extracts the namespace (or pod_namespace) label and the trailing value from
+// a single Prometheus sample line, e.g.
+// container_network_receive_bytes_total{namespace="ns-foo",interface="eth0"} 1024
+func parseSample(line string) (namespace string, value int64, ok bool) {
+	open := strings.IndexByte(line, '{')
+	end := strings.IndexByte(line, '}')
+	if open < 0 || end < 0 || end < open {
+		return "", 0, false
+	}
+	labels := line[open+1 : end]
+	for _, kv := range strings.Split(labels, ",") {
+		kv = strings.TrimSpace(kv)
+		if strings.HasPrefix(kv, "namespace=") || strings.HasPrefix(kv, "pod_namespace=") {
+			namespace = strings.Trim(kv[strings.IndexByte(kv, '=')+1:], `"`)
+		}
+	}
+	valueStr := strings.TrimSpace(line[end+1:])
+	v, err := strconv.ParseFloat(valueStr, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return namespace, int64(v), true
+}
@@ -0,0 +1,175 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package network meters per-namespace pod egress/ingress bandwidth and
+// writes it into the monitor collection as a first-class property, the
+// same way the cpu/memory/storage collectors already do.
+package network
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/labring/sealos/controllers/pkg/resources"
+	"github.com/labring/sealos/pkg/utils/logger"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Sampler returns the cumulative ingress/egress byte counters for every pod
+// on a node, keyed by namespace. A cAdvisor-backed implementation reads
+// container_network_receive_bytes_total / container_network_transmit_bytes_total
+// off the node's /metrics/cadvisor endpoint; other implementations (eBPF,
+// conntrack) can satisfy the same interface.
+type Sampler interface {
+	Sample(ctx context.Context, node string) (map[string]NamespaceCounters, error)
+}
+
+// NamespaceCounters holds the raw, monotonically increasing byte counters
+// observed for a namespace since node boot.
+type NamespaceCounters struct {
+	IngressBytes int64
+	EgressBytes  int64
+}
+
+// Collector periodically samples every node and writes the delta since the
+// previous sample into the monitor collection as Monitor documents, so that
+// Metering and Billing rollups pick network usage up automatically.
+type Collector struct {
+	Client      kubernetes.Interface
+	MongoClient *mongo.Client
+	Sampler     Sampler
+	// UnitPeriod is the sampling/metering interval; it mirrors
+	// PropertyType.UnitPeriod for the network property.
+	UnitPeriod time.Duration
+
+	last map[string]NamespaceCounters
+}
+
+// Run samples on UnitPeriod until ctx is cancelled.
+func (c *Collector) Run(ctx context.Context) error {
+	if c.UnitPeriod == 0 {
+		c.UnitPeriod = time.Hour
+	}
+	if c.last == nil {
+		c.last = make(map[string]NamespaceCounters)
+	}
+	ticker := time.NewTicker(c.UnitPeriod)
+	defer ticker.Stop()
+	for {
+		if err := c.collectOnce(ctx); err != nil {
+			logger.Warn("network collector: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Collector) collectOnce(ctx context.Context) error {
+	ingressEnum, egressEnum, err := networkEnums()
+	if err != nil {
+		return err
+	}
+
+	nodes, err := c.Client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("list nodes: %v", err)
+	}
+	totals := make(map[string]NamespaceCounters)
+	for i := range nodes.Items {
+		perNS, err := c.Sampler.Sample(ctx, nodes.Items[i].Name)
+		if err != nil {
+			logger.Warn("sample node %s: %v", nodes.Items[i].Name, err)
+			continue
+		}
+		for ns, counters := range perNS {
+			agg := totals[ns]
+			agg.IngressBytes += counters.IngressBytes
+			agg.EgressBytes += counters.EgressBytes
+			totals[ns] = agg
+		}
+	}
+	return c.writeMonitorDocs(ctx, totals, ingressEnum, egressEnum)
+}
+
+// networkEnums looks up the "network-ingress"/"network-egress" enums in the
+// active catalog and confirms both are actually present. A plain map lookup
+// (StringMap["network-ingress"].Enum) would silently return the zero value
+// on a miss, which is cpu's enum - corrupting CPU billing with network
+// bytes instead of failing loudly.
+func networkEnums() (ingress, egress uint8, err error) {
+	ls := resources.ActivePropertyTypeLS()
+	ingressType, ok := ls.StringMap["network-ingress"]
+	if !ok {
+		return 0, 0, fmt.Errorf("active property catalog has no %q property", "network-ingress")
+	}
+	egressType, ok := ls.StringMap["network-egress"]
+	if !ok {
+		return 0, 0, fmt.Errorf("active property catalog has no %q property", "network-egress")
+	}
+	return ingressType.Enum, egressType.Enum, nil
+}
+
+func (c *Collector) writeMonitorDocs(ctx context.Context, totals map[string]NamespaceCounters, ingressEnum, egressEnum uint8) error {
+	collection := c.MongoClient.Database(resources.SealosResourcesDBName).Collection(resources.SealosMonitorCollectionName)
+	now := time.Now().UTC()
+	for ns, cur := range totals {
+		prev := c.last[ns]
+		c.last[ns] = cur
+		ingressDelta := deltaMiB(prev.IngressBytes, cur.IngressBytes)
+		egressDelta := deltaMiB(prev.EgressBytes, cur.EgressBytes)
+		if ingressDelta == 0 && egressDelta == 0 {
+			continue
+		}
+		doc := resources.Monitor{
+			Time:     now,
+			Category: ns,
+			Type:     resources.AppType[resources.OTHER],
+			Name:     resources.ResourceNetwork,
+			Property: resources.ResourceNetwork,
+			Used: resources.EnumUsedMap{
+				ingressEnum: ingressDelta,
+				egressEnum:  egressDelta,
+			},
+		}
+		if _, err := collection.InsertOne(ctx, doc); err != nil {
+			return fmt.Errorf("insert network monitor doc for %s: %v", ns, err)
+		}
+	}
+	return nil
+}
+
+// deltaMiB converts a byte counter delta into the same 1Mi unit the
+// network PropertyType bills in, rounding the way GetResourceValue does.
+// A node restart resets the cAdvisor counter, which would otherwise show up
+// as a negative delta; treat that case as "no usage yet" rather than
+// underbilling the namespace.
+func deltaMiB(prev, cur int64) int64 {
+	if cur <= prev {
+		return 0
+	}
+	q := resource.NewQuantity(cur-prev, resource.BinarySI)
+	return resources.GetResourceValue(resources.ResourceNetwork, map[corev1.ResourceName]*resources.QuantityDetail{
+		resources.ResourceNetwork: {Quantity: q},
+	})
+}
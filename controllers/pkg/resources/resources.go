@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"math"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/labring/sealos/controllers/pkg/crypto"
@@ -122,6 +123,17 @@ type Billing struct {
 	Payment *Payment `json:"payment" bson:"payment,omitempty"`
 	// if type = Transfer, then transfer is not nil
 	Transfer *Transfer `json:"transfer" bson:"transfer,omitempty"`
+
+	// Seq is a strictly increasing, per-Owner chain position assigned by
+	// AppendBillingReceipt. Unlike Time, two documents can never share a
+	// Seq, so it - not Time - is what PrevHash/Hash actually chain on.
+	Seq int64 `json:"seq,omitempty" bson:"seq,omitempty"`
+	// PrevHash is the Hash of the previous Billing doc for this Owner,
+	// chaining this entry onto it; empty for the first entry in the chain.
+	PrevHash string `json:"prev_hash,omitempty" bson:"prev_hash,omitempty"`
+	// Hash is HMAC-SHA256(canonical_json(doc without Hash) || PrevHash),
+	// keyed by BillingChainHMACKeyEnv; see ComputeBillingHash/VerifyBillingChain.
+	Hash string `json:"hash,omitempty" bson:"hash,omitempty"`
 }
 
 type Payment struct {
@@ -225,6 +237,23 @@ type PropertyType struct {
 	UnitString string `json:"unit" bson:"unit"`
 	//计费周期 second
 	UnitPeriod string `json:"unit_period,omitempty" bson:"unit_period,omitempty"`
+	// Tiers enables S3-style lifecycle pricing (hot/warm/cold): when set,
+	// usage is billed per StorageTier instead of a single UnitPrice. A
+	// PropertyType with no Tiers behaves exactly as before - see
+	// EffectiveTiers, which treats UnitPrice/EncryptUnitPrice as an implicit
+	// single, ageless tier so existing catalogs keep working unmodified.
+	Tiers []StorageTier `json:"tiers,omitempty" bson:"tiers,omitempty"`
+}
+
+// StorageTier is one lifecycle step of tiered storage pricing, e.g.
+// hot (age 0), warm (age >= 30d), cold (age >= 90d).
+type StorageTier struct {
+	// MinAgeSeconds is the minimum object age, in seconds, for this tier to
+	// apply. Tiers are matched by the largest MinAgeSeconds <= the object's
+	// age, so exactly one tier ever applies to a given age.
+	MinAgeSeconds    int64  `json:"min_age_seconds" bson:"min_age_seconds"`
+	UnitPrice        int64  `json:"unit_price" bson:"unit_price"`
+	EncryptUnitPrice string `json:"encrypt_unit_price,omitempty" bson:"encrypt_unit_price,omitempty"`
 }
 
 type PropertyTypeLS struct {
@@ -262,14 +291,74 @@ var DefaultPropertyTypeList = []PropertyType{
 		UnitPrice:  781,
 		UnitString: "1Mi",
 	},
+	{
+		Name:       "network-ingress",
+		Enum:       4,
+		PriceType:  "SUM",
+		UnitPrice:  781,
+		UnitString: "1Mi",
+	},
+	{
+		Name:       "network-egress",
+		Enum:       5,
+		PriceType:  "SUM",
+		UnitPrice:  781,
+		UnitString: "1Mi",
+	},
+}
+
+// RollupValue folds a window of raw samples for a property into the single
+// value that gets stored on a Metering document, following PropertyType's
+// PriceType:
+//   - AVG (default): the mean of the samples, e.g. cpu/memory/storage
+//     occupancy, which is meaningless to sum across a window.
+//   - SUM: the total of the samples, e.g. network bandwidth counters, where
+//     each sample is a delta and the billed amount is the accumulated total.
+func RollupValue(pt PropertyType, samples []int64) int64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	var sum int64
+	for _, s := range samples {
+		sum += s
+	}
+	if strings.EqualFold(pt.PriceType, "SUM") {
+		return sum
+	}
+	return int64(math.Round(float64(sum) / float64(len(samples))))
 }
 
 var DefaultPropertyTypeLS = newPropertyTypeLS(DefaultPropertyTypeList)
 
+// propertyTypeLSMu guards DefaultPropertyTypeLS so a PriceCatalog reconcile
+// can swap the active catalog while other goroutines are reading it, without
+// a restart.
+var propertyTypeLSMu sync.RWMutex
+
+// ActivePropertyTypeLS returns the property catalog currently in effect.
+// It starts out built from DefaultPropertyTypeList; while no PriceCatalog CR
+// exists, the PriceCatalog reconciler overlays unit prices from MongoDB onto
+// it, and once a CR is reconciled the CR's catalog replaces it; see
+// SetActivePropertyTypeLS.
+func ActivePropertyTypeLS() *PropertyTypeLS {
+	propertyTypeLSMu.RLock()
+	defer propertyTypeLSMu.RUnlock()
+	return DefaultPropertyTypeLS
+}
+
+// SetActivePropertyTypeLS atomically swaps the active property catalog,
+// e.g. when the PriceCatalog controller reconciles a new spec.
+func SetActivePropertyTypeLS(ls *PropertyTypeLS) {
+	propertyTypeLSMu.Lock()
+	defer propertyTypeLSMu.Unlock()
+	DefaultPropertyTypeLS = ls
+}
+
 func ConvertEnumUsedToString(costs map[uint8]int64) (costsMap map[string]int64) {
+	ls := ActivePropertyTypeLS()
 	costsMap = make(map[string]int64, len(costs))
 	for k, v := range costs {
-		costsMap[DefaultPropertyTypeLS.EnumMap[k].Name] = v
+		costsMap[ls.EnumMap[k].Name] = v
 	}
 	return
 }
@@ -283,6 +372,16 @@ func NewPropertyTypeLS(types []PropertyType) (ls *PropertyTypeLS) {
 	return newPropertyTypeLS(types)
 }
 
+// NewPropertyTypeLSFromPlainPrices builds a PropertyTypeLS from types whose
+// UnitPrice is already plaintext, skipping decryptPrice entirely. Use this
+// instead of NewPropertyTypeLS for sources - like the legacy "prices"
+// collection - that never populate EncryptUnitPrice: decryptPrice requires
+// every entry to carry one, so NewPropertyTypeLS would discard these types
+// and silently fall back to DefaultPropertyTypeList.
+func NewPropertyTypeLSFromPlainPrices(types []PropertyType) (ls *PropertyTypeLS) {
+	return newPropertyTypeLS(types)
+}
+
 func newPropertyTypeLS(types []PropertyType) (ls *PropertyTypeLS) {
 	ls = &PropertyTypeLS{
 		Types:     types,
@@ -348,12 +447,97 @@ type QuantityDetail struct {
 }
 
 const (
-	SealosResourcesDBName        = "sealos-resources"
-	SealosMonitorCollectionName  = "monitor"
-	SealosPricesCollectionName   = "prices"
-	SealosMeteringCollectionName = "metering"
-	SealosBillingCollectionName  = "billing"
+	SealosResourcesDBName          = "sealos-resources"
+	SealosMonitorCollectionName    = "monitor"
+	SealosPricesCollectionName     = "prices"
+	SealosMeteringCollectionName   = "metering"
+	SealosBillingCollectionName    = "billing"
+	SealosStorageAgeCollectionName = "storage_age"
 )
+
+// StorageAge records how long a unit of stored data (a PVC, a minio object)
+// has sat untouched, as of a single inventory scan. The metering job reads
+// recent StorageAge documents for a namespace to split its storage usage
+// into tier buckets; see TieredStorageAmount.
+type StorageAge struct {
+	Time time.Time `json:"time" bson:"time"`
+	// Category is the namespace, matching Monitor.Category.
+	Category string `json:"category" bson:"category"`
+	// Name identifies the scanned object (PVC name, bucket/object key).
+	Name string `json:"name" bson:"name"`
+	// SizeMiB is the object's size, already converted to the storage
+	// property's 1Mi unit.
+	SizeMiB int64 `json:"size_mi" bson:"size_mi"`
+	// AgeSeconds is how long the object has been idle (time since its last
+	// write/access), used to pick a StorageTier.
+	AgeSeconds int64 `json:"age_seconds" bson:"age_seconds"`
+}
+
+// EffectiveTiers returns pt.Tiers with any EncryptUnitPrice decrypted into
+// UnitPrice, or - when pt carries no tiers - a single ageless tier built
+// from its own UnitPrice/EncryptUnitPrice. The no-tiers case is the
+// migration path: a PropertyType written before tiered pricing existed
+// bills exactly as it always has.
+func EffectiveTiers(pt PropertyType) []StorageTier {
+	if len(pt.Tiers) == 0 {
+		return []StorageTier{{MinAgeSeconds: 0, UnitPrice: pt.UnitPrice, EncryptUnitPrice: pt.EncryptUnitPrice}}
+	}
+	return decryptTierPrices(pt.Tiers)
+}
+
+// decryptTierPrices overwrites each tier's UnitPrice from EncryptUnitPrice
+// where one is set, the same way decryptPrice does for a plain
+// PropertyType. Unlike decryptPrice, a tier without EncryptUnitPrice is not
+// an error: tiered catalogs may ship plaintext UnitPrice directly, so only
+// the tiers that opted into encryption are decrypted.
+func decryptTierPrices(tiers []StorageTier) []StorageTier {
+	out := make([]StorageTier, len(tiers))
+	copy(out, tiers)
+	for i := range out {
+		if out[i].EncryptUnitPrice == "" {
+			continue
+		}
+		price, err := crypto.DecryptInt64(out[i].EncryptUnitPrice)
+		if err != nil {
+			logger.Warn("failed to decrypt storage tier unit price : %v", err)
+			continue
+		}
+		out[i].UnitPrice = price
+	}
+	return out
+}
+
+// tierForAge returns the tier with the largest MinAgeSeconds <= age. Tiers
+// need not be pre-sorted. It assumes at least one tier has MinAgeSeconds 0
+// (EffectiveTiers guarantees this for the single-tier migration path).
+func tierForAge(tiers []StorageTier, age int64) StorageTier {
+	best := tiers[0]
+	found := false
+	for _, t := range tiers {
+		if t.MinAgeSeconds <= age && (!found || t.MinAgeSeconds >= best.MinAgeSeconds) {
+			best = t
+			found = true
+		}
+	}
+	return best
+}
+
+// TieredStorageAmount splits a namespace's StorageAge samples into tier
+// buckets by age and sums Amount = SizeMiB * UnitPrice per tier, replacing
+// the flat UsedAmount / Unit * UnitPrice calculation for the storage
+// property. Re-tiering falls out for free: each call re-evaluates every
+// sample's current AgeSeconds against the tiers, so an object that has aged
+// past a boundary since the last scan is billed at its new tier.
+func TieredStorageAmount(pt PropertyType, ages []StorageAge) int64 {
+	tiers := EffectiveTiers(pt)
+	var amount int64
+	for _, a := range ages {
+		tier := tierForAge(tiers, a.AgeSeconds)
+		amount += a.SizeMiB * tier.UnitPrice
+	}
+	return amount
+}
+
 const (
 	PropertyInfraCPU    = "infra-cpu"
 	PropertyInfraMemory = "infra-memory"
@@ -366,6 +550,14 @@ const GpuResourcePrefix = "gpu-"
 const ResourceGPU corev1.ResourceName = gpu.NvidiaGpuKey
 const ResourceNetwork = "network"
 
+// ResourceNetworkIngress and ResourceNetworkEgress let the network collector
+// meter inbound and outbound traffic separately instead of folding both
+// directions into the single "network" enum.
+const (
+	ResourceNetworkIngress corev1.ResourceName = "network-ingress"
+	ResourceNetworkEgress  corev1.ResourceName = "network-egress"
+)
+
 const (
 	ResourceRequestGpu corev1.ResourceName = "requests." + gpu.NvidiaGpuKey
 	ResourceLimitGpu   corev1.ResourceName = "limits." + gpu.NvidiaGpuKey
@@ -391,6 +583,8 @@ var PricesUnit = map[corev1.ResourceName]*resource.Quantity{
 	corev1.ResourceMemory:  bin1Mi,   // 1 MiB
 	corev1.ResourceStorage: bin1Mi,   // 1 MiB
 	ResourceNetwork:        bin1Mi,   // 1 MiB
+	ResourceNetworkIngress: bin1Mi,   // 1 MiB
+	ResourceNetworkEgress:  bin1Mi,   // 1 MiB
 }
 
 var DefaultPrices = map[string]Price{
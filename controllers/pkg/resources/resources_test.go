@@ -0,0 +1,149 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestGetResourceValueNetworkRoundsAgainstBin1Mi(t *testing.T) {
+	tests := []struct {
+		name     string
+		bytes    int64
+		expected int64
+	}{
+		{name: "exact 1Mi", bytes: 1 << 20, expected: 1},
+		{name: "under 1Mi rounds up", bytes: (1 << 20) - 1, expected: 1},
+		{name: "just over 1Mi rounds up to 2", bytes: (1 << 20) + 1, expected: 2},
+		{name: "zero bytes bills nothing", bytes: 0, expected: 0},
+		{name: "several whole Mi", bytes: 5 * (1 << 20), expected: 5},
+		// A single 1 kbps sample (125 bytes) is a tiny fraction of 1Mi but
+		// still rounds up to a whole billable unit, not down to zero.
+		{name: "single kilobit sample rounds up to 1", bytes: 125, expected: 1},
+		// 64 kbps over a 1s sample window (8,000 bytes) - still well under
+		// 1Mi, still rounds up to 1.
+		{name: "64 kilobit sample rounds up to 1", bytes: 8000, expected: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := map[corev1.ResourceName]*QuantityDetail{
+				ResourceNetwork: {Quantity: resource.NewQuantity(tt.bytes, resource.BinarySI)},
+			}
+			if got := GetResourceValue(ResourceNetwork, res); got != tt.expected {
+				t.Errorf("GetResourceValue(%d bytes) = %d, want %d", tt.bytes, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRollupValueSumVsAvg(t *testing.T) {
+	samples := []int64{10, 20, 30}
+
+	avg := RollupValue(PropertyType{PriceType: "AVG"}, samples)
+	if avg != 20 {
+		t.Errorf("AVG rollup = %d, want 20", avg)
+	}
+
+	sum := RollupValue(PropertyType{PriceType: "SUM"}, samples)
+	if sum != 60 {
+		t.Errorf("SUM rollup = %d, want 60", sum)
+	}
+
+	if got := RollupValue(PropertyType{PriceType: "SUM"}, nil); got != 0 {
+		t.Errorf("SUM rollup of no samples = %d, want 0", got)
+	}
+}
+
+func tieredStorageProperty() PropertyType {
+	return PropertyType{
+		Name: "storage",
+		Tiers: []StorageTier{
+			{MinAgeSeconds: 0, UnitPrice: 2},             // hot
+			{MinAgeSeconds: 30 * 24 * 3600, UnitPrice: 1}, // warm, 30d
+			{MinAgeSeconds: 90 * 24 * 3600, UnitPrice: 0}, // cold, 90d (archival, near-free)
+		},
+	}
+}
+
+func TestTieredStorageAmountBoundaryAges(t *testing.T) {
+	pt := tieredStorageProperty()
+	tests := []struct {
+		name   string
+		age    int64
+		wantPx int64
+	}{
+		{name: "fresh object is hot", age: 0, wantPx: 2},
+		{name: "one second short of warm stays hot", age: 30*24*3600 - 1, wantPx: 2},
+		{name: "exactly the warm boundary is warm", age: 30 * 24 * 3600, wantPx: 1},
+		{name: "one second short of cold stays warm", age: 90*24*3600 - 1, wantPx: 1},
+		{name: "exactly the cold boundary is cold", age: 90 * 24 * 3600, wantPx: 0},
+		{name: "well past cold stays cold", age: 365 * 24 * 3600, wantPx: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := TieredStorageAmount(pt, []StorageAge{{SizeMiB: 10, AgeSeconds: tt.age}})
+			if want := 10 * tt.wantPx; got != want {
+				t.Errorf("TieredStorageAmount(age=%d) = %d, want %d", tt.age, got, want)
+			}
+		})
+	}
+}
+
+func TestTieredStorageAmountReTiersAfterIdlePeriod(t *testing.T) {
+	pt := tieredStorageProperty()
+	sample := StorageAge{SizeMiB: 100, AgeSeconds: 10}
+
+	firstScan := TieredStorageAmount(pt, []StorageAge{sample})
+	if firstScan != 200 {
+		t.Fatalf("first scan amount = %d, want 200 (hot tier)", firstScan)
+	}
+
+	// The same object, now idle long enough to have rolled into the cold
+	// tier - nothing tracks its previous bucket, the next scan just
+	// re-evaluates its current age.
+	sample.AgeSeconds = 120 * 24 * 3600
+	secondScan := TieredStorageAmount(pt, []StorageAge{sample})
+	if secondScan != 0 {
+		t.Fatalf("second scan amount = %d, want 0 (cold tier)", secondScan)
+	}
+}
+
+func TestEffectiveTiersMigratesSingleUnitPrice(t *testing.T) {
+	pt := PropertyType{Name: "storage", UnitPrice: 2}
+	tiers := EffectiveTiers(pt)
+	if len(tiers) != 1 || tiers[0].MinAgeSeconds != 0 || tiers[0].UnitPrice != 2 {
+		t.Errorf("EffectiveTiers(no Tiers) = %+v, want a single ageless tier at UnitPrice 2", tiers)
+	}
+}
+
+func TestEffectiveTiersLeavesPlaintextTiersAlone(t *testing.T) {
+	// A tier with no EncryptUnitPrice is plaintext already: EffectiveTiers
+	// must not touch its UnitPrice or error, unlike decryptPrice's
+	// all-or-nothing requirement for a plain PropertyType.
+	pt := PropertyType{
+		Name: "storage",
+		Tiers: []StorageTier{
+			{MinAgeSeconds: 0, UnitPrice: 2},
+			{MinAgeSeconds: 30 * 24 * 3600, UnitPrice: 1},
+		},
+	}
+	tiers := EffectiveTiers(pt)
+	if len(tiers) != 2 || tiers[0].UnitPrice != 2 || tiers[1].UnitPrice != 1 {
+		t.Errorf("EffectiveTiers(plaintext Tiers) = %+v, want UnitPrice unchanged", tiers)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package storage scans PVC inventory and writes it into the storage_age
+// collection, so the metering rollup can bill storage with
+// resources.TieredStorageAmount instead of a flat UnitPrice.
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/labring/sealos/controllers/pkg/resources"
+	"github.com/labring/sealos/pkg/utils/logger"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Collector periodically scans every PVC in the cluster and records its size
+// and age as a StorageAge document, one inventory scan at a time.
+type Collector struct {
+	Client      kubernetes.Interface
+	MongoClient *mongo.Client
+	// ScanInterval is how often the cluster is scanned; it defaults to 1
+	// hour, matching the metering rollup's default UnitPeriod.
+	ScanInterval time.Duration
+}
+
+// Run scans on ScanInterval until ctx is cancelled.
+func (c *Collector) Run(ctx context.Context) error {
+	if c.ScanInterval == 0 {
+		c.ScanInterval = time.Hour
+	}
+	ticker := time.NewTicker(c.ScanInterval)
+	defer ticker.Stop()
+	for {
+		if err := c.scanOnce(ctx); err != nil {
+			logger.Warn("storage inventory scan: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Collector) scanOnce(ctx context.Context) error {
+	pvcs, err := c.Client.CoreV1().PersistentVolumeClaims(corev1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("list persistent volume claims: %v", err)
+	}
+
+	now := time.Now()
+	collection := c.MongoClient.Database(resources.SealosResourcesDBName).Collection(resources.SealosStorageAgeCollectionName)
+	for i := range pvcs.Items {
+		pvc := &pvcs.Items[i]
+		doc := resources.StorageAge{
+			Time:     now.UTC(),
+			Category: pvc.Namespace,
+			Name:     pvc.Name,
+			SizeMiB:  sizeMiB(pvc),
+			// A PVC carries no last-accessed timestamp, only its creation
+			// time, so age here is time since creation rather than time
+			// since last write/access - a PVC that is still being actively
+			// written to will be billed as if it were idle. A more precise
+			// age needs an access-time source this cluster doesn't expose
+			// (e.g. filesystem atime via an agent), which is out of scope
+			// here.
+			AgeSeconds: int64(now.Sub(pvc.CreationTimestamp.Time).Seconds()),
+		}
+		if _, err := collection.InsertOne(ctx, doc); err != nil {
+			return fmt.Errorf("insert storage age doc for %s/%s: %v", pvc.Namespace, pvc.Name, err)
+		}
+	}
+	return nil
+}
+
+// sizeMiB converts a PVC's provisioned capacity (Status.Capacity) into the storage property's
+// 1Mi unit, the same way network's deltaMiB does for bandwidth.
+func sizeMiB(pvc *corev1.PersistentVolumeClaim) int64 {
+	capacity, ok := pvc.Status.Capacity[corev1.ResourceStorage]
+	if !ok {
+		return 0
+	}
+	q := resource.NewQuantity(capacity.Value(), resource.BinarySI)
+	return resources.GetResourceValue(corev1.ResourceStorage, map[corev1.ResourceName]*resources.QuantityDetail{
+		corev1.ResourceStorage: {Quantity: q},
+	})
+}